@@ -0,0 +1,202 @@
+package junos
+
+import (
+	"errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultConnectTimeout is used when SessionConfig.ConnectTimeout is left at
+// its zero value.
+const defaultConnectTimeout = 30 * time.Second
+
+// AuthMethod produces the ssh.AuthMethod(s) used to authenticate a session.
+// PasswordAuth, KeyAuth, AgentAuth, CertificateAuth, and
+// KeyboardInteractiveAuth implement it.
+type AuthMethod interface {
+	authMethods() ([]ssh.AuthMethod, error)
+}
+
+// PasswordAuth authenticates with a plaintext password.
+type PasswordAuth struct {
+	Password string
+}
+
+func (a PasswordAuth) authMethods() ([]ssh.AuthMethod, error) {
+	return []ssh.AuthMethod{ssh.Password(a.Password)}, nil
+}
+
+// KeyAuth authenticates with a private key file, optionally protected by a
+// Passphrase.
+type KeyAuth struct {
+	KeyFile    string
+	Passphrase string
+}
+
+func (a KeyAuth) authMethods() ([]ssh.AuthMethod, error) {
+	signer, err := loadSigner(a.KeyFile, a.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+}
+
+// AgentAuth authenticates through a running ssh-agent, reached via the
+// SSH_AUTH_SOCK environment variable.
+type AgentAuth struct{}
+
+func (AgentAuth) authMethods() ([]ssh.AuthMethod, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, errors.New("SSH_AUTH_SOCK is not set; is ssh-agent running?")
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, err
+	}
+
+	return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}, nil
+}
+
+// CertificateAuth authenticates with an OpenSSH certificate (CertFile) and
+// the private key (KeyFile) it was issued for.
+type CertificateAuth struct {
+	CertFile   string
+	KeyFile    string
+	Passphrase string
+}
+
+func (a CertificateAuth) authMethods() ([]ssh.AuthMethod, error) {
+	signer, err := loadSigner(a.KeyFile, a.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	certData, err := ioutil.ReadFile(a.CertFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(certData)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("CertFile does not contain an OpenSSH certificate")
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return []ssh.AuthMethod{ssh.PublicKeys(certSigner)}, nil
+}
+
+// KeyboardInteractiveAuth authenticates via SSH keyboard-interactive,
+// answering each prompt with the corresponding entry in Answers.
+type KeyboardInteractiveAuth struct {
+	Answers []string
+}
+
+func (a KeyboardInteractiveAuth) authMethods() ([]ssh.AuthMethod, error) {
+	challenge := func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i := range questions {
+			if i < len(a.Answers) {
+				answers[i] = a.Answers[i]
+			}
+		}
+
+		return answers, nil
+	}
+
+	return []ssh.AuthMethod{ssh.KeyboardInteractive(challenge)}, nil
+}
+
+// loadSigner parses a private key file, decrypting it with passphrase if one
+// is given.
+func loadSigner(keyFile, passphrase string) (ssh.Signer, error) {
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if passphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+
+	return ssh.ParsePrivateKey(data)
+}
+
+// InsecureIgnoreHostKey returns a HostKeyCallback that accepts any host key
+// without verification. Only use this for testing, or when the device's
+// host key is already pinned out of band.
+func InsecureIgnoreHostKey() ssh.HostKeyCallback {
+	return ssh.InsecureIgnoreHostKey()
+}
+
+// defaultHostKeyCallback verifies host keys against the user's
+// ~/.ssh/known_hosts file. A missing file is treated as an empty
+// known_hosts set rather than an error: building a session config must not
+// mutate the filesystem, so every host key is simply left unrecognized
+// (and therefore rejected) until the file exists.
+func defaultHostKeyCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(home, ".ssh", "known_hosts")
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return knownhosts.New()
+	}
+
+	return knownhosts.New(path)
+}
+
+// buildSSHConfig assembles an ssh.ClientConfig from cfg, filling in the
+// password-auth, known_hosts, and timeout defaults that NewSessionContext
+// relies on.
+func buildSSHConfig(cfg SessionConfig) (*ssh.ClientConfig, error) {
+	auth := cfg.Auth
+	if auth == nil {
+		auth = PasswordAuth{}
+	}
+
+	methods, err := auth.authMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback := cfg.HostKeyCallback
+	if hostKeyCallback == nil {
+		hostKeyCallback, err = defaultHostKeyCallback()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	timeout := cfg.ConnectTimeout
+	if timeout == 0 {
+		timeout = defaultConnectTimeout
+	}
+
+	return &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package junos
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBuildSSHConfigDefaults(t *testing.T) {
+	cfg, err := buildSSHConfig(SessionConfig{User: "admin", HostKeyCallback: InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatalf("buildSSHConfig() error = %v", err)
+	}
+
+	if cfg.User != "admin" {
+		t.Errorf("User = %q, want %q", cfg.User, "admin")
+	}
+
+	if len(cfg.Auth) != 1 {
+		t.Fatalf("got %d auth methods, want 1 (defaulted PasswordAuth)", len(cfg.Auth))
+	}
+
+	if cfg.Timeout != defaultConnectTimeout {
+		t.Errorf("Timeout = %v, want %v (defaultConnectTimeout)", cfg.Timeout, defaultConnectTimeout)
+	}
+}
+
+func TestBuildSSHConfigExplicitConnectTimeout(t *testing.T) {
+	cfg, err := buildSSHConfig(SessionConfig{ConnectTimeout: 5 * time.Second, HostKeyCallback: InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatalf("buildSSHConfig() error = %v", err)
+	}
+
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want %v", cfg.Timeout, 5*time.Second)
+	}
+}
+
+func TestBuildSSHConfigExplicitAuth(t *testing.T) {
+	cfg, err := buildSSHConfig(SessionConfig{Auth: PasswordAuth{Password: "secret"}, HostKeyCallback: InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatalf("buildSSHConfig() error = %v", err)
+	}
+
+	if len(cfg.Auth) != 1 {
+		t.Fatalf("got %d auth methods, want 1", len(cfg.Auth))
+	}
+}
+
+func TestBuildSSHConfigAuthError(t *testing.T) {
+	sock, had := os.LookupEnv("SSH_AUTH_SOCK")
+	os.Unsetenv("SSH_AUTH_SOCK")
+	defer func() {
+		if had {
+			os.Setenv("SSH_AUTH_SOCK", sock)
+		}
+	}()
+
+	_, err := buildSSHConfig(SessionConfig{Auth: AgentAuth{}})
+	if err == nil {
+		t.Fatal("buildSSHConfig() error = nil, want an error when SSH_AUTH_SOCK is unset")
+	}
+}
+
+func TestBuildSSHConfigExplicitHostKeyCallback(t *testing.T) {
+	// Supplying HostKeyCallback must bypass defaultHostKeyCallback entirely
+	// (which reads ~/.ssh/known_hosts and would otherwise make this test
+	// depend on the environment it runs in).
+	cfg, err := buildSSHConfig(SessionConfig{HostKeyCallback: InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatalf("buildSSHConfig() error = %v", err)
+	}
+
+	if cfg.HostKeyCallback == nil {
+		t.Fatal("HostKeyCallback = nil, want the explicit callback to be used")
+	}
+}
@@ -0,0 +1,384 @@
+package junos
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// RPC calls used by the high-availability subsystem.
+var (
+	rpcClusterStatus     = "<get-chassis-cluster-status/>"
+	rpcGRESStatus        = "<get-database-replication-summary-information/>"
+	rpcNSRStatus         = "<get-task-replication-state/>"
+	rpcClusterFailover   = "<request-chassis-cluster-failover redundancy-group=\"%d\" node=\"%d\"/>"
+	rpcClusterSwitchover = "<request-chassis-routing-engine-master-switch/>"
+	rpcClusterResetCount = "<request-chassis-cluster-failover-reset><redundancy-group>%d</redundancy-group></request-chassis-cluster-failover-reset>"
+)
+
+// ClusterStatus holds the chassis-cluster redundancy state of an SRX cluster,
+// as reported by <get-chassis-cluster-status/>.
+type ClusterStatus struct {
+	RedundancyGroups []RedundancyGroup
+}
+
+// RedundancyGroup describes the state of a single chassis-cluster redundancy
+// group. Priority, PreemptState, FlapReason, and FailoverCount are
+// per-node, since the two nodes of a cluster routinely disagree on them.
+type RedundancyGroup struct {
+	ID                 int
+	Node0State         string
+	Node1State         string
+	Node0Priority      int
+	Node1Priority      int
+	Node0PreemptState  string
+	Node1PreemptState  string
+	Node0FlapReason    string
+	Node1FlapReason    string
+	Node0FailoverCount int
+	Node1FailoverCount int
+}
+
+// REStatus holds routing-engine mastership, health, and uptime information,
+// as reported by <get-route-engine-information/>.
+type REStatus struct {
+	Slot            int
+	MastershipState string
+	Status          string
+	MemoryUtilized  int
+	Temperature     string
+	UpTime          string
+}
+
+// GRESInfo holds graceful-RE-switchover replication state, as reported by
+// <get-database-replication-summary-information/>.
+type GRESInfo struct {
+	Enabled bool
+	Status  string
+}
+
+// NSRInfo holds non-stop-routing replication state, as reported by
+// <get-task-replication-state/>.
+type NSRInfo struct {
+	Enabled bool
+	Status  string
+}
+
+type clusterStatusXML struct {
+	XMLName xml.Name `xml:"multi-routing-engine-results"`
+	Items   []struct {
+		Groups []struct {
+			ID      int `xml:"redundancy-group-id"`
+			Devices []struct {
+				Name           string `xml:"device-name"`
+				Priority       int    `xml:"priority"`
+				CurrentState   string `xml:"current-state"`
+				PreemptState   string `xml:"preempt-state"`
+				LastFlapReason string `xml:"last-flap-reason"`
+				FailoverCount  int    `xml:"monitor-failures>failover-count"`
+			} `xml:"device-stats"`
+		} `xml:"redundancy-group-information>redundancy-group"`
+	} `xml:"multi-routing-engine-item"`
+}
+
+type reStatusXML struct {
+	XMLName xml.Name `xml:"route-engine-information"`
+	RE      []struct {
+		Slot            int    `xml:"slot"`
+		MastershipState string `xml:"mastership-state"`
+		Status          string `xml:"status"`
+		MemoryUtilized  int    `xml:"memory-buffer-utilization"`
+		Temperature     string `xml:"temperature"`
+		UpTime          string `xml:"up-time"`
+	} `xml:"route-engine"`
+}
+
+type gresStatusXML struct {
+	XMLName xml.Name `xml:"database-replication-summary-information"`
+	Enabled string   `xml:"database-replication-enabled"`
+	Status  string   `xml:"database-replication-status"`
+}
+
+type nsrStatusXML struct {
+	XMLName xml.Name `xml:"task-replication-state"`
+	Enabled string   `xml:"task-nsr-enabled"`
+	Status  string   `xml:"task-replication-state"`
+}
+
+// ChassisClusterStatus returns the chassis-cluster redundancy-group state of
+// an SRX cluster.
+func (j *Junos) ChassisClusterStatus() (*ClusterStatus, error) {
+	return j.ChassisClusterStatusContext(context.Background())
+}
+
+// ChassisClusterStatusContext is ChassisClusterStatus with a caller-supplied
+// context.
+func (j *Junos) ChassisClusterStatusContext(ctx context.Context) (*ClusterStatus, error) {
+	reply, err := j.execContext(ctx, rpcClusterStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Errors != nil {
+		for _, m := range reply.Errors {
+			return nil, errors.New(m.Message)
+		}
+	}
+
+	return parseClusterStatus(reply.Data)
+}
+
+// parseClusterStatus unmarshals a <get-chassis-cluster-status/> reply and
+// folds its per-device entries into ClusterStatus's per-node fields. Split
+// out of ChassisClusterStatusContext so the folding logic can be tested
+// without a live device.
+func parseClusterStatus(data string) (*ClusterStatus, error) {
+	var parsed clusterStatusXML
+	if err := xml.Unmarshal([]byte(data), &parsed); err != nil {
+		return nil, err
+	}
+
+	var status ClusterStatus
+	for _, item := range parsed.Items {
+		for _, g := range item.Groups {
+			group := RedundancyGroup{ID: g.ID}
+			for _, d := range g.Devices {
+				switch d.Name {
+				case "node0":
+					group.Node0State = d.CurrentState
+					group.Node0Priority = d.Priority
+					group.Node0PreemptState = d.PreemptState
+					group.Node0FlapReason = d.LastFlapReason
+					group.Node0FailoverCount = d.FailoverCount
+				case "node1":
+					group.Node1State = d.CurrentState
+					group.Node1Priority = d.Priority
+					group.Node1PreemptState = d.PreemptState
+					group.Node1FlapReason = d.LastFlapReason
+					group.Node1FailoverCount = d.FailoverCount
+				}
+			}
+
+			status.RedundancyGroups = append(status.RedundancyGroups, group)
+		}
+	}
+
+	return &status, nil
+}
+
+// RouteEngineStatus returns the mastership, health, and uptime of every
+// Routing Engine in the chassis, ordered as reported by
+// <get-route-engine-information/>. That RPC reports every RE regardless of
+// which one the session is attached to, so there is no entry here that can
+// be singled out as "the local RE" without the caller already knowing its
+// slot.
+func (j *Junos) RouteEngineStatus() ([]REStatus, error) {
+	return j.RouteEngineStatusContext(context.Background())
+}
+
+// RouteEngineStatusContext is RouteEngineStatus with a caller-supplied
+// context.
+func (j *Junos) RouteEngineStatusContext(ctx context.Context) ([]REStatus, error) {
+	reply, err := j.execContext(ctx, rpcFactsRE)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Errors != nil {
+		for _, m := range reply.Errors {
+			return nil, errors.New(m.Message)
+		}
+	}
+
+	if reply.Data == "" {
+		return nil, errors.New("could not load route engine status")
+	}
+
+	return parseREStatus(reply.Data)
+}
+
+// parseREStatus unmarshals a <get-route-engine-information/> reply into one
+// REStatus per routing engine reported. Split out of
+// RouteEngineStatusContext so the folding logic can be tested without a live
+// device.
+func parseREStatus(data string) ([]REStatus, error) {
+	var parsed reStatusXML
+	if err := xml.Unmarshal([]byte(data), &parsed); err != nil {
+		return nil, err
+	}
+
+	if len(parsed.RE) == 0 {
+		return nil, errors.New("could not load route engine status")
+	}
+
+	statuses := make([]REStatus, 0, len(parsed.RE))
+	for _, re := range parsed.RE {
+		statuses = append(statuses, REStatus{
+			Slot:            re.Slot,
+			MastershipState: re.MastershipState,
+			Status:          re.Status,
+			MemoryUtilized:  re.MemoryUtilized,
+			Temperature:     re.Temperature,
+			UpTime:          re.UpTime,
+		})
+	}
+
+	return statuses, nil
+}
+
+// IsMasterRE reports whether the Routing Engine in the given slot is
+// currently master. Callers must supply the slot themselves: as explained
+// on RouteEngineStatus, there is no way to derive "the RE handling this
+// session" from <get-route-engine-information/> alone.
+func (j *Junos) IsMasterRE(slot int) (bool, error) {
+	return j.IsMasterREContext(context.Background(), slot)
+}
+
+// IsMasterREContext is IsMasterRE with a caller-supplied context.
+func (j *Junos) IsMasterREContext(ctx context.Context, slot int) (bool, error) {
+	statuses, err := j.RouteEngineStatusContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, status := range statuses {
+		if status.Slot == slot {
+			return status.MastershipState == "master", nil
+		}
+	}
+
+	return false, fmt.Errorf("no routing engine reported in slot %d", slot)
+}
+
+// GRESStatus returns the graceful-RE-switchover replication state between
+// Routing Engines.
+func (j *Junos) GRESStatus() (*GRESInfo, error) {
+	return j.GRESStatusContext(context.Background())
+}
+
+// GRESStatusContext is GRESStatus with a caller-supplied context.
+func (j *Junos) GRESStatusContext(ctx context.Context) (*GRESInfo, error) {
+	var parsed gresStatusXML
+	reply, err := j.execContext(ctx, rpcGRESStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Errors != nil {
+		for _, m := range reply.Errors {
+			return nil, errors.New(m.Message)
+		}
+	}
+
+	err = xml.Unmarshal([]byte(reply.Data), &parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GRESInfo{
+		Enabled: parsed.Enabled == "Enabled",
+		Status:  parsed.Status,
+	}, nil
+}
+
+// NSRStatus returns the non-stop-routing replication state between Routing
+// Engines.
+func (j *Junos) NSRStatus() (*NSRInfo, error) {
+	return j.NSRStatusContext(context.Background())
+}
+
+// NSRStatusContext is NSRStatus with a caller-supplied context.
+func (j *Junos) NSRStatusContext(ctx context.Context) (*NSRInfo, error) {
+	var parsed nsrStatusXML
+	reply, err := j.execContext(ctx, rpcNSRStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Errors != nil {
+		for _, m := range reply.Errors {
+			return nil, errors.New(m.Message)
+		}
+	}
+
+	err = xml.Unmarshal([]byte(reply.Data), &parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NSRInfo{
+		Enabled: parsed.Enabled == "Enabled",
+		Status:  parsed.Status,
+	}, nil
+}
+
+// RequestFailover triggers a chassis-cluster failover of the given redundancy
+// group to toNode.
+func (j *Junos) RequestFailover(group, toNode int) error {
+	return j.RequestFailoverContext(context.Background(), group, toNode)
+}
+
+// RequestFailoverContext is RequestFailover with a caller-supplied context.
+func (j *Junos) RequestFailoverContext(ctx context.Context, group, toNode int) error {
+	command := fmt.Sprintf(rpcClusterFailover, group, toNode)
+	reply, err := j.execContext(ctx, command)
+	if err != nil {
+		return err
+	}
+
+	if reply.Errors != nil {
+		for _, m := range reply.Errors {
+			return errors.New(m.Message)
+		}
+	}
+
+	return nil
+}
+
+// RequestSwitchover triggers a routing-engine mastership switchover on a
+// dual-RE chassis.
+func (j *Junos) RequestSwitchover() error {
+	return j.RequestSwitchoverContext(context.Background())
+}
+
+// RequestSwitchoverContext is RequestSwitchover with a caller-supplied
+// context.
+func (j *Junos) RequestSwitchoverContext(ctx context.Context) error {
+	reply, err := j.execContext(ctx, rpcClusterSwitchover)
+	if err != nil {
+		return err
+	}
+
+	if reply.Errors != nil {
+		for _, m := range reply.Errors {
+			return errors.New(m.Message)
+		}
+	}
+
+	return nil
+}
+
+// ResetFailoverCount clears the recorded failover count for the given
+// redundancy group.
+func (j *Junos) ResetFailoverCount(group int) error {
+	return j.ResetFailoverCountContext(context.Background(), group)
+}
+
+// ResetFailoverCountContext is ResetFailoverCount with a caller-supplied
+// context.
+func (j *Junos) ResetFailoverCountContext(ctx context.Context, group int) error {
+	command := fmt.Sprintf(rpcClusterResetCount, group)
+	reply, err := j.execContext(ctx, command)
+	if err != nil {
+		return err
+	}
+
+	if reply.Errors != nil {
+		for _, m := range reply.Errors {
+			return errors.New(m.Message)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package junos
+
+import "testing"
+
+func TestParseClusterStatus(t *testing.T) {
+	const data = `
+<multi-routing-engine-results>
+  <multi-routing-engine-item>
+    <redundancy-group-information>
+      <redundancy-group>
+        <redundancy-group-id>0</redundancy-group-id>
+        <device-stats>
+          <device-name>node0</device-name>
+          <priority>1</priority>
+          <current-state>primary</current-state>
+          <preempt-state>disabled</preempt-state>
+          <last-flap-reason>Manual switch</last-flap-reason>
+          <monitor-failures>
+            <failover-count>2</failover-count>
+          </monitor-failures>
+        </device-stats>
+        <device-stats>
+          <device-name>node1</device-name>
+          <priority>200</priority>
+          <current-state>secondary</current-state>
+          <preempt-state>enabled</preempt-state>
+          <last-flap-reason>Hold timer expired</last-flap-reason>
+          <monitor-failures>
+            <failover-count>5</failover-count>
+          </monitor-failures>
+        </device-stats>
+      </redundancy-group>
+    </redundancy-group-information>
+  </multi-routing-engine-item>
+</multi-routing-engine-results>`
+
+	status, err := parseClusterStatus(data)
+	if err != nil {
+		t.Fatalf("parseClusterStatus() error = %v", err)
+	}
+
+	if len(status.RedundancyGroups) != 1 {
+		t.Fatalf("got %d redundancy groups, want 1", len(status.RedundancyGroups))
+	}
+
+	got := status.RedundancyGroups[0]
+	want := RedundancyGroup{
+		ID:                 0,
+		Node0State:         "primary",
+		Node1State:         "secondary",
+		Node0Priority:      1,
+		Node1Priority:      200,
+		Node0PreemptState:  "disabled",
+		Node1PreemptState:  "enabled",
+		Node0FlapReason:    "Manual switch",
+		Node1FlapReason:    "Hold timer expired",
+		Node0FailoverCount: 2,
+		Node1FailoverCount: 5,
+	}
+
+	if got != want {
+		t.Errorf("RedundancyGroups[0] = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseClusterStatusMultipleGroups(t *testing.T) {
+	const data = `
+<multi-routing-engine-results>
+  <multi-routing-engine-item>
+    <redundancy-group-information>
+      <redundancy-group>
+        <redundancy-group-id>0</redundancy-group-id>
+        <device-stats>
+          <device-name>node0</device-name>
+          <current-state>primary</current-state>
+        </device-stats>
+      </redundancy-group>
+      <redundancy-group>
+        <redundancy-group-id>1</redundancy-group-id>
+        <device-stats>
+          <device-name>node1</device-name>
+          <current-state>primary</current-state>
+        </device-stats>
+      </redundancy-group>
+    </redundancy-group-information>
+  </multi-routing-engine-item>
+</multi-routing-engine-results>`
+
+	status, err := parseClusterStatus(data)
+	if err != nil {
+		t.Fatalf("parseClusterStatus() error = %v", err)
+	}
+
+	if len(status.RedundancyGroups) != 2 {
+		t.Fatalf("got %d redundancy groups, want 2", len(status.RedundancyGroups))
+	}
+
+	if status.RedundancyGroups[0].ID != 0 || status.RedundancyGroups[0].Node0State != "primary" {
+		t.Errorf("RedundancyGroups[0] = %+v", status.RedundancyGroups[0])
+	}
+
+	if status.RedundancyGroups[1].ID != 1 || status.RedundancyGroups[1].Node1State != "primary" {
+		t.Errorf("RedundancyGroups[1] = %+v", status.RedundancyGroups[1])
+	}
+}
+
+func TestParseREStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []REStatus
+		wantErr bool
+	}{
+		{
+			name: "single RE",
+			data: `
+<route-engine-information>
+  <route-engine>
+    <slot>0</slot>
+    <mastership-state>master</mastership-state>
+    <status>OK</status>
+    <memory-buffer-utilization>12</memory-buffer-utilization>
+    <temperature>42 degrees C</temperature>
+    <up-time>10 days, 2 hours</up-time>
+  </route-engine>
+</route-engine-information>`,
+			want: []REStatus{
+				{
+					Slot:            0,
+					MastershipState: "master",
+					Status:          "OK",
+					MemoryUtilized:  12,
+					Temperature:     "42 degrees C",
+					UpTime:          "10 days, 2 hours",
+				},
+			},
+		},
+		{
+			name: "dual RE, every entry is kept",
+			data: `
+<route-engine-information>
+  <route-engine>
+    <slot>0</slot>
+    <mastership-state>master</mastership-state>
+  </route-engine>
+  <route-engine>
+    <slot>1</slot>
+    <mastership-state>backup</mastership-state>
+  </route-engine>
+</route-engine-information>`,
+			want: []REStatus{
+				{Slot: 0, MastershipState: "master"},
+				{Slot: 1, MastershipState: "backup"},
+			},
+		},
+		{
+			name:    "no route engines",
+			data:    `<route-engine-information></route-engine-information>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseREStatus(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseREStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseREStatus() = %+v, want %+v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseREStatus()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -3,24 +3,23 @@
 package junos
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"github.com/scottdware/go-netconf/netconf"
+	"golang.org/x/crypto/ssh"
 	"io/ioutil"
-	"log"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
 // All of our RPC calls we use.
 var (
 	rpcCommand            = "<command format=\"text\">%s</command>"
 	rpcCommandXML         = "<command format=\"xml\">%s</command>"
-	rpcCommit             = "<commit-configuration/>"
-	rpcCommitAt           = "<commit-configuration><at-time>%s</at-time></commit-configuration>"
-	rpcCommitCheck        = "<commit-configuration><check/></commit-configuration>"
-	rpcCommitConfirm      = "<commit-configuration><confirmed/><confirm-timeout>%d</confirm-timeout></commit-configuration>"
 	rpcFactsRE            = "<get-route-engine-information/>"
 	rpcFactsChassis       = "<get-chassis-inventory/>"
 	rpcConfigFileSet      = "<load-configuration action=\"set\" format=\"text\"><configuration-set>%s</configuration-set></load-configuration>"
@@ -56,6 +55,15 @@ type Junos struct {
 	Hostname       string
 	RoutingEngines int
 	Platform       []RoutingEngine
+	logger         Logger
+	stopKeepalive  chan struct{}
+	closeOnce      sync.Once
+
+	// rpcMu serializes access to Session.Exec. netconf.Session.Exec sends
+	// then reads the transport with no locking of its own, so without this
+	// a keepalive tick can interleave with an in-flight caller RPC and
+	// corrupt both replies.
+	rpcMu sync.Mutex
 }
 
 // CommitHistory holds all of the commit entries.
@@ -82,9 +90,10 @@ type commandXML struct {
 }
 
 type commitError struct {
-	Path    string `xml:"error-path"`
-	Element string `xml:"error-info>bad-element"`
-	Message string `xml:"error-message"`
+	Path     string `xml:"error-path"`
+	Element  string `xml:"error-info>bad-element"`
+	Message  string `xml:"error-message"`
+	Severity string `xml:"error-severity"`
 }
 
 type commitResults struct {
@@ -92,6 +101,129 @@ type commitResults struct {
 	Errors  []commitError `xml:"rpc-error"`
 }
 
+// CommitOptions controls how CommitWith assembles a commit-configuration RPC.
+// The zero value performs a plain commit.
+type CommitOptions struct {
+	// Full forces all daemons to re-check the candidate configuration rather
+	// than relying on the incremental check Junos normally performs. Useful
+	// after large changes that trip the incremental checker.
+	Full bool
+
+	// Synchronize commits the configuration on both Routing Engines of a
+	// dual-RE chassis.
+	Synchronize bool
+
+	// Force commits even if one Routing Engine has an unsynchronized
+	// configuration. Only meaningful when Synchronize is true.
+	Force bool
+
+	// PrepareOnly runs the first phase of a two-phase commit: the candidate
+	// is checked and staged, but not activated.
+	PrepareOnly bool
+
+	// CommitPrepared activates a configuration previously staged with
+	// PrepareOnly.
+	CommitPrepared bool
+
+	// Confirmed requires a follow-up commit within ConfirmTimeout minutes,
+	// after which Junos automatically rolls back the configuration.
+	Confirmed bool
+
+	// ConfirmTimeout is the rollback window, in minutes, used when Confirmed
+	// is true. Junos defaults to 10 minutes when this is left at zero.
+	ConfirmTimeout int
+
+	// At schedules the commit for a future time, e.g. "now + 1 hour" or
+	// "2015-10-21 07:28:00".
+	At string
+
+	// CheckOnly validates the candidate configuration without committing it.
+	CheckOnly bool
+
+	// LogMessage is recorded alongside the commit in the commit history.
+	LogMessage string
+}
+
+// CommitError describes a single <rpc-error> (or warning) returned in a
+// commit-configuration reply.
+type CommitError struct {
+	Path    string
+	Element string
+	Message string
+	Warning bool
+}
+
+// Error satisfies the error interface.
+func (e CommitError) Error() string {
+	return fmt.Sprintf("[%s]\n    %s\nError: %s", e.Path, e.Element, e.Message)
+}
+
+// CommitErrors is the set of errors and warnings returned by a commit. It
+// implements error so CommitWith can return it directly, while still letting
+// callers range over the individual entries.
+type CommitErrors []CommitError
+
+// Error satisfies the error interface.
+func (c CommitErrors) Error() string {
+	messages := make([]string, len(c))
+	for i, e := range c {
+		messages[i] = e.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// buildCommitCommand composes a <commit-configuration> RPC from opts.
+func buildCommitCommand(opts CommitOptions) string {
+	var command strings.Builder
+	command.WriteString("<commit-configuration>")
+
+	if opts.CheckOnly {
+		command.WriteString("<check/>")
+	}
+
+	if opts.Full {
+		command.WriteString("<full/>")
+	}
+
+	if opts.Synchronize {
+		if opts.Force {
+			command.WriteString("<force-synchronize/>")
+		} else {
+			command.WriteString("<synchronize/>")
+		}
+	}
+
+	if opts.PrepareOnly {
+		command.WriteString("<prepare/>")
+	}
+
+	if opts.CommitPrepared {
+		command.WriteString("<commit/>")
+	}
+
+	if opts.Confirmed {
+		timeout := opts.ConfirmTimeout
+		if timeout == 0 {
+			timeout = 10
+		}
+		command.WriteString("<confirmed/>")
+		command.WriteString(fmt.Sprintf("<confirm-timeout>%d</confirm-timeout>", timeout))
+	}
+
+	if opts.At != "" {
+		command.WriteString(fmt.Sprintf("<at-time>%s</at-time>", opts.At))
+	}
+
+	if opts.LogMessage != "" {
+		command.WriteString(fmt.Sprintf("<log>%s</log>", opts.LogMessage))
+	}
+
+	command.WriteString("</commit-configuration>")
+
+	return command.String()
+}
+
 type diffXML struct {
 	XMLName xml.Name `xml:"rollback-information"`
 	Config  string   `xml:"configuration-information>configuration-output"`
@@ -150,14 +282,118 @@ type File struct {
 	} `xml:"file-date"`
 }
 
-// Close disconnects our session to the device.
+// Close disconnects our session to the device. It is safe to call more than
+// once.
 func (j *Junos) Close() {
-	j.Session.Transport.Close()
+	j.closeOnce.Do(func() {
+		if j.stopKeepalive != nil {
+			close(j.stopKeepalive)
+		}
+
+		j.Session.Transport.Close()
+	})
+}
+
+// startKeepalive periodically sends a lightweight RPC so long-lived sessions
+// survive NAT and firewall idle timers. It no-ops when interval is zero.
+func (j *Junos) startKeepalive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	logger := j.logger
+	if logger == nil {
+		logger = noopLogger{}
+	}
+
+	j.stopKeepalive = make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := j.exec(rpcVersion); err != nil {
+					logger.Warn("keepalive failed", "error", err)
+				}
+			case <-j.stopKeepalive:
+				return
+			}
+		}
+	}()
+}
+
+// execResult carries the outcome of a goroutine-bound RPC exec back to the
+// caller selecting on ctx.Done().
+type execResult struct {
+	reply *netconf.RPCReply
+	err   error
+}
+
+// execContext sends command to the device as a raw RPC, reporting the
+// outgoing RPC and the outcome of the reply through the configured Logger.
+// The RPC runs in its own goroutine so that a cancelled or expired ctx can
+// return control to the caller immediately; on cancellation the underlying
+// transport is closed so the RE session actually tears down rather than
+// leaking a goroutine blocked on the reply.
+func (j *Junos) execContext(ctx context.Context, command string) (*netconf.RPCReply, error) {
+	logger := j.logger
+	if logger == nil {
+		logger = noopLogger{}
+	}
+
+	logger.Debug("sending rpc", "rpc", command)
+
+	done := make(chan execResult, 1)
+	go func() {
+		j.rpcMu.Lock()
+		defer j.rpcMu.Unlock()
+
+		reply, err := j.Session.Exec(netconf.RawRPC(command))
+		done <- execResult{reply: reply, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		logger.Error("rpc canceled", "rpc", command, "error", ctx.Err())
+		j.Session.Transport.Close()
+		return nil, ctx.Err()
+	case res := <-done:
+		if res.err != nil {
+			logger.Error("rpc exec failed", "rpc", command, "error", res.err)
+			return res.reply, res.err
+		}
+
+		if res.reply.Errors != nil {
+			for _, m := range res.reply.Errors {
+				logger.Error("rpc returned error", "rpc", command, "message", m.Message)
+			}
+		} else {
+			logger.Info("rpc succeeded", "rpc", command)
+		}
+
+		return res.reply, nil
+	}
+}
+
+// exec sends command to the device as a raw RPC. It is equivalent to
+// execContext with context.Background().
+func (j *Junos) exec(command string) (*netconf.RPCReply, error) {
+	return j.execContext(context.Background(), command)
 }
 
 // RunCommand executes any operational mode command, such as "show" or "request."
 // <format> can be one of "text" or "xml."
 func (j *Junos) RunCommand(cmd, format string) (string, error) {
+	return j.RunCommandContext(context.Background(), cmd, format)
+}
+
+// RunCommandContext is RunCommand with a caller-supplied context. If ctx is
+// canceled or expires before the device replies, the session's transport is
+// closed and ctx.Err() is returned.
+func (j *Junos) RunCommandContext(ctx context.Context, cmd, format string) (string, error) {
 	var command string
 	command = fmt.Sprintf(rpcCommand, cmd)
 	errMessage := "No output available. Please check the syntax of your command."
@@ -166,7 +402,7 @@ func (j *Junos) RunCommand(cmd, format string) (string, error) {
 		command = fmt.Sprintf(rpcCommandXML, cmd)
 	}
 
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
+	reply, err := j.execContext(ctx, command)
 	if err != nil {
 		return errMessage, err
 	}
@@ -196,8 +432,13 @@ func (j *Junos) RunCommand(cmd, format string) (string, error) {
 
 // CommitHistory gathers all the information about previous commits.
 func (j *Junos) CommitHistory() (*CommitHistory, error) {
+	return j.CommitHistoryContext(context.Background())
+}
+
+// CommitHistoryContext is CommitHistory with a caller-supplied context.
+func (j *Junos) CommitHistoryContext(ctx context.Context) (*CommitHistory, error) {
 	var history CommitHistory
-	reply, err := j.Session.Exec(netconf.RawRPC(rpcCommitHistory))
+	reply, err := j.execContext(ctx, rpcCommitHistory)
 	if err != nil {
 		return nil, err
 	}
@@ -220,10 +461,23 @@ func (j *Junos) CommitHistory() (*CommitHistory, error) {
 	return &history, nil
 }
 
-// Commit commits the configuration.
-func (j *Junos) Commit() error {
-	var errs commitResults
-	reply, err := j.Session.Exec(netconf.RawRPC(rpcCommit))
+// CommitWith commits the configuration using the flavor described by opts. It
+// is the single entry point that Commit, CommitAt, CommitCheck, and
+// CommitConfirm wrap; call it directly to combine flavors, such as a
+// synchronized, confirmed commit on a dual-RE chassis. If the commit-results
+// in the reply hold any rpc-errors, CommitWith returns them as a
+// CommitErrors; commit-results containing only warnings are a successful
+// commit, so CommitWith returns nil for them. An rpc-error at the top level of
+// the reply, outside commit-results, is returned as a plain error carrying
+// only the first such error's message, not as a CommitErrors.
+func (j *Junos) CommitWith(opts CommitOptions) error {
+	return j.CommitWithContext(context.Background(), opts)
+}
+
+// CommitWithContext is CommitWith with a caller-supplied context.
+func (j *Junos) CommitWithContext(ctx context.Context, opts CommitOptions) error {
+	command := buildCommitCommand(opts)
+	reply, err := j.execContext(ctx, command)
 	if err != nil {
 		return err
 	}
@@ -234,115 +488,94 @@ func (j *Junos) Commit() error {
 		}
 	}
 
-	err = xml.Unmarshal([]byte(reply.Data), &errs)
-	if err != nil {
-		return err
-	}
-
-	if errs.Errors != nil {
-		for _, m := range errs.Errors {
-			message := fmt.Sprintf("[%s]\n    %s\nError: %s", strings.Trim(m.Path, "[\r\n]"), strings.Trim(m.Element, "[\r\n]"), strings.Trim(m.Message, "[\r\n]"))
-			return errors.New(message)
-		}
-	}
-
-	return nil
+	return parseCommitResults(reply.Data)
 }
 
-// CommitAt commits the configuration at the specified <time>.
-func (j *Junos) CommitAt(time string) error {
+// parseCommitResults unmarshals a <commit-configuration> reply and folds any
+// rpc-errors into a CommitErrors. A reply where every entry is a warning
+// describes a commit that succeeded, so parseCommitResults returns nil for
+// it rather than a CommitErrors. Split out of CommitWithContext so the
+// folding logic can be tested without a live device.
+func parseCommitResults(data string) error {
 	var errs commitResults
-	command := fmt.Sprintf(rpcCommitAt, time)
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
-	if err != nil {
+	if err := xml.Unmarshal([]byte(data), &errs); err != nil {
 		return err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
+	if errs.Errors == nil {
+		return nil
 	}
 
-	err = xml.Unmarshal([]byte(reply.Data), &errs)
-	if err != nil {
-		return err
+	results := make(CommitErrors, 0, len(errs.Errors))
+	onlyWarnings := true
+	for _, m := range errs.Errors {
+		warning := m.Severity == "warning"
+		onlyWarnings = onlyWarnings && warning
+		results = append(results, CommitError{
+			Path:    strings.Trim(m.Path, "[\r\n]"),
+			Element: strings.Trim(m.Element, "[\r\n]"),
+			Message: strings.Trim(m.Message, "[\r\n]"),
+			Warning: warning,
+		})
 	}
 
-	if errs.Errors != nil {
-		for _, m := range errs.Errors {
-			message := fmt.Sprintf("[%s]\n    %s\nError: %s", strings.Trim(m.Path, "[\r\n]"), strings.Trim(m.Element, "[\r\n]"), strings.Trim(m.Message, "[\r\n]"))
-			return errors.New(message)
-		}
+	if onlyWarnings {
+		return nil
 	}
 
-	return nil
+	return results
 }
 
-// CommitCheck checks the configuration for syntax errors.
-func (j *Junos) CommitCheck() error {
-	var errs commitResults
-	reply, err := j.Session.Exec(netconf.RawRPC(rpcCommitCheck))
-	if err != nil {
-		return err
-	}
+// Commit commits the configuration.
+func (j *Junos) Commit() error {
+	return j.CommitWith(CommitOptions{})
+}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
-	}
+// CommitContext is Commit with a caller-supplied context.
+func (j *Junos) CommitContext(ctx context.Context) error {
+	return j.CommitWithContext(ctx, CommitOptions{})
+}
 
-	err = xml.Unmarshal([]byte(reply.Data), &errs)
-	if err != nil {
-		return err
-	}
+// CommitAt commits the configuration at the specified <time>.
+func (j *Junos) CommitAt(time string) error {
+	return j.CommitWith(CommitOptions{At: time})
+}
 
-	if errs.Errors != nil {
-		for _, m := range errs.Errors {
-			message := fmt.Sprintf("[%s]\n    %s\nError: %s", strings.Trim(m.Path, "[\r\n]"), strings.Trim(m.Element, "[\r\n]"), strings.Trim(m.Message, "[\r\n]"))
-			return errors.New(message)
-		}
-	}
+// CommitAtContext is CommitAt with a caller-supplied context.
+func (j *Junos) CommitAtContext(ctx context.Context, time string) error {
+	return j.CommitWithContext(ctx, CommitOptions{At: time})
+}
 
-	return nil
+// CommitCheck checks the configuration for syntax errors.
+func (j *Junos) CommitCheck() error {
+	return j.CommitWith(CommitOptions{CheckOnly: true})
+}
+
+// CommitCheckContext is CommitCheck with a caller-supplied context.
+func (j *Junos) CommitCheckContext(ctx context.Context) error {
+	return j.CommitWithContext(ctx, CommitOptions{CheckOnly: true})
 }
 
 // CommitConfirm rolls back the configuration after <delay> minutes.
 func (j *Junos) CommitConfirm(delay int) error {
-	var errs commitResults
-	command := fmt.Sprintf(rpcCommitConfirm, delay)
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
-	if err != nil {
-		return err
-	}
-
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
-	}
-
-	err = xml.Unmarshal([]byte(reply.Data), &errs)
-	if err != nil {
-		return err
-	}
-
-	if errs.Errors != nil {
-		for _, m := range errs.Errors {
-			message := fmt.Sprintf("[%s]\n    %s\nError: %s", strings.Trim(m.Path, "[\r\n]"), strings.Trim(m.Element, "[\r\n]"), strings.Trim(m.Message, "[\r\n]"))
-			return errors.New(message)
-		}
-	}
+	return j.CommitWith(CommitOptions{Confirmed: true, ConfirmTimeout: delay})
+}
 
-	return nil
+// CommitConfirmContext is CommitConfirm with a caller-supplied context.
+func (j *Junos) CommitConfirmContext(ctx context.Context, delay int) error {
+	return j.CommitWithContext(ctx, CommitOptions{Confirmed: true, ConfirmTimeout: delay})
 }
 
 // ConfigDiff compares the current active configuration to a given rollback configuration.
 func (j *Junos) ConfigDiff(compare int) (string, error) {
+	return j.ConfigDiffContext(context.Background(), compare)
+}
+
+// ConfigDiffContext is ConfigDiff with a caller-supplied context.
+func (j *Junos) ConfigDiffContext(ctx context.Context, compare int) (string, error) {
 	var rb diffXML
 	command := fmt.Sprintf(rpcGetRollbackCompare, compare)
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
+	reply, err := j.execContext(ctx, command)
 	if err != nil {
 		return "", err
 	}
@@ -391,6 +624,11 @@ func (j *Junos) PrintFacts() {
 // <format> can be one of "text" or "xml." You can do sub-sections by separating the
 // <section> path with a ">" symbol, i.e. "system>login" or "protocols>ospf>area".
 func (j *Junos) GetConfig(section, format string) (string, error) {
+	return j.GetConfigContext(context.Background(), section, format)
+}
+
+// GetConfigContext is GetConfig with a caller-supplied context.
+func (j *Junos) GetConfigContext(ctx context.Context, section, format string) (string, error) {
 	secs := strings.Split(section, ">")
 	nSecs := len(secs) - 1
 	command := fmt.Sprintf("<get-configuration format=\"%s\"><configuration>", format)
@@ -408,7 +646,7 @@ func (j *Junos) GetConfig(section, format string) (string, error) {
 		command += fmt.Sprint("</configuration></get-configuration>")
 	}
 
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
+	reply, err := j.execContext(ctx, command)
 	if err != nil {
 		return "", err
 	}
@@ -437,6 +675,11 @@ func (j *Junos) GetConfig(section, format string) (string, error) {
 // from variables (type string or []string) within your script. Format can be one of
 // "set" "text" or "xml."
 func (j *Junos) Config(path interface{}, format string, commit bool) error {
+	return j.ConfigContext(context.Background(), path, format, commit)
+}
+
+// ConfigContext is Config with a caller-supplied context.
+func (j *Junos) ConfigContext(ctx context.Context, path interface{}, format string, commit bool) error {
 	var command string
 	switch format {
 	case "set":
@@ -501,13 +744,13 @@ func (j *Junos) Config(path interface{}, format string, commit bool) error {
 		}
 	}
 
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
+	reply, err := j.execContext(ctx, command)
 	if err != nil {
 		return err
 	}
 
 	if commit {
-		err = j.Commit()
+		err = j.CommitContext(ctx)
 		if err != nil {
 			return err
 		}
@@ -524,7 +767,12 @@ func (j *Junos) Config(path interface{}, format string, commit bool) error {
 
 // Lock locks the candidate configuration.
 func (j *Junos) Lock() error {
-	reply, err := j.Session.Exec(netconf.RawRPC(rpcLock))
+	return j.LockContext(context.Background())
+}
+
+// LockContext is Lock with a caller-supplied context.
+func (j *Junos) LockContext(ctx context.Context) error {
+	reply, err := j.execContext(ctx, rpcLock)
 	if err != nil {
 		return err
 	}
@@ -538,17 +786,119 @@ func (j *Junos) Lock() error {
 	return nil
 }
 
-// NewSession establishes a new connection to a Junos device that we will use
-// to run our commands against. NewSession also gathers software information
-// about the device.
-func NewSession(host, user, password string) (*Junos, error) {
-	rex := regexp.MustCompile(`^.*\[(.*)\]`)
-	s, err := netconf.DialSSH(host, netconf.SSHConfigPassword(user, password))
+// Logger is the interface RPC activity is reported through. kv is a sequence
+// of alternating key/value pairs, so implementations can be wired directly
+// into an existing structured-logging stack (hclog, zap, slog, ...).
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// noopLogger is the default Logger: it discards everything.
+type noopLogger struct{}
+
+func (noopLogger) Debug(msg string, kv ...interface{}) {}
+func (noopLogger) Info(msg string, kv ...interface{})  {}
+func (noopLogger) Warn(msg string, kv ...interface{})  {}
+func (noopLogger) Error(msg string, kv ...interface{}) {}
+
+// SessionConfig carries everything needed to establish a Junos session. Use
+// NewSessionWithConfig when you need control beyond what NewSession exposes,
+// such as a custom Logger or a non-password AuthMethod.
+type SessionConfig struct {
+	Host string
+	Port int
+	User string
+
+	// Auth selects how the SSH session authenticates. Defaults to
+	// PasswordAuth{} (an empty password) when nil.
+	Auth AuthMethod
+
+	// HostKeyCallback verifies the server's host key. Defaults to checking
+	// against ~/.ssh/known_hosts; use InsecureIgnoreHostKey to disable
+	// verification.
+	HostKeyCallback ssh.HostKeyCallback
+
+	// ConnectTimeout bounds the SSH dial. Defaults to 30 seconds when zero.
+	ConnectTimeout time.Duration
+
+	// KeepAliveInterval, when non-zero, sends a lightweight RPC on this
+	// interval so long-lived sessions survive NAT/firewall idle timers.
+	KeepAliveInterval time.Duration
+
+	// Logger receives Debug/Info/Error events for every RPC sent on the
+	// session. Defaults to a no-op Logger when nil.
+	Logger Logger
+}
+
+// NewSessionWithConfig establishes a new connection to a Junos device using
+// the settings in cfg, and gathers software information about the device.
+// Dial failures are returned to the caller rather than aborting the process.
+func NewSessionWithConfig(cfg SessionConfig) (*Junos, error) {
+	return NewSessionContext(context.Background(), cfg)
+}
+
+// NewSessionContext is NewSessionWithConfig with a caller-supplied context.
+// Both the SSH dial and the initial <get-software-information/> RPC are
+// cancellable; on cancellation any transport that was opened is closed.
+func NewSessionContext(ctx context.Context, cfg SessionConfig) (*Junos, error) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = noopLogger{}
+	}
+
+	host := cfg.Host
+	if cfg.Port != 0 {
+		host = fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	}
+
+	sshConfig, err := buildSSHConfig(cfg)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("ssh config invalid", "host", cfg.Host, "error", err)
+		return nil, err
+	}
+
+	type dialResult struct {
+		session *netconf.Session
+		err     error
 	}
 
-	reply, err := s.Exec(netconf.RawRPC(rpcVersion))
+	dialed := make(chan dialResult, 1)
+	go func() {
+		s, err := netconf.DialSSH(host, sshConfig)
+		dialed <- dialResult{session: s, err: err}
+	}()
+
+	var s *netconf.Session
+	select {
+	case <-ctx.Done():
+		logger.Error("ssh dial canceled", "host", cfg.Host, "error", ctx.Err())
+
+		// The dial goroutine may still succeed after we've given up on it;
+		// drain its result once it arrives and close the transport so a
+		// cancel racing a successful dial doesn't leak the SSH connection.
+		go func() {
+			res := <-dialed
+			if res.err == nil && res.session != nil {
+				res.session.Transport.Close()
+			}
+		}()
+
+		return nil, ctx.Err()
+	case res := <-dialed:
+		if res.err != nil {
+			logger.Error("ssh dial failed", "host", cfg.Host, "error", res.err)
+			return nil, res.err
+		}
+		s = res.session
+	}
+
+	rex := regexp.MustCompile(`^.*\[(.*)\]`)
+	j := &Junos{Session: s, logger: logger}
+
+	reply, err := j.execContext(ctx, rpcVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -576,12 +926,13 @@ func NewSession(host, user, password string) (*Junos, error) {
 			res = append(res, RoutingEngine{Model: model, Version: version[1]})
 		}
 
-		return &Junos{
-			Session:        s,
-			Hostname:       hostname,
-			RoutingEngines: numRE,
-			Platform:       res,
-		}, nil
+		j.Hostname = hostname
+		j.RoutingEngines = numRE
+		j.Platform = res
+
+		j.startKeepalive(cfg.KeepAliveInterval)
+
+		return j, nil
 	}
 
 	var facts versionRouteEngine
@@ -596,23 +947,70 @@ func NewSession(host, user, password string) (*Junos, error) {
 	model := strings.ToUpper(facts.Platform)
 	res = append(res, RoutingEngine{Model: model, Version: version[1]})
 
-	return &Junos{
-		Session:        s,
-		Hostname:       hostname,
-		RoutingEngines: 1,
-		Platform:       res,
-	}, nil
+	j.Hostname = hostname
+	j.RoutingEngines = 1
+	j.Platform = res
+
+	j.startKeepalive(cfg.KeepAliveInterval)
+
+	return j, nil
+}
+
+// NewSession establishes a new connection to a Junos device that we will use
+// to run our commands against. NewSession also gathers software information
+// about the device.
+//
+// NewSession preserves the host-key handling of the netconf package's old
+// SSHConfigPassword helper it used to be built on (ssh.InsecureIgnoreHostKey),
+// so existing callers keep connecting without a ~/.ssh/known_hosts entry.
+// Use NewSessionWithConfig with an explicit HostKeyCallback to verify host
+// keys.
+//
+// Deprecated: use NewSessionWithConfig, which lets you wire in a Logger, a
+// non-password AuthMethod, or host-key verification.
+func NewSession(host, user, password string) (*Junos, error) {
+	return NewSessionWithConfig(SessionConfig{
+		Host:            host,
+		User:            user,
+		Auth:            PasswordAuth{Password: password},
+		HostKeyCallback: InsecureIgnoreHostKey(),
+	})
+}
+
+// NewSessionWithKey establishes a new session authenticating with a private
+// key file, optionally protected by passphrase.
+func NewSessionWithKey(host, user, keyFile, passphrase string) (*Junos, error) {
+	return NewSessionWithConfig(SessionConfig{
+		Host: host,
+		User: user,
+		Auth: KeyAuth{KeyFile: keyFile, Passphrase: passphrase},
+	})
+}
+
+// NewSessionWithAgent establishes a new session authenticating through a
+// running ssh-agent, reached via the SSH_AUTH_SOCK environment variable.
+func NewSessionWithAgent(host, user string) (*Junos, error) {
+	return NewSessionWithConfig(SessionConfig{
+		Host: host,
+		User: user,
+		Auth: AgentAuth{},
+	})
 }
 
 // Rescue will create or delete the rescue configuration given "save" or "delete."
 func (j *Junos) Rescue(action string) error {
+	return j.RescueContext(context.Background(), action)
+}
+
+// RescueContext is Rescue with a caller-supplied context.
+func (j *Junos) RescueContext(ctx context.Context, action string) error {
 	command := fmt.Sprintf(rpcRescueSave)
 
 	if action == "delete" {
 		command = fmt.Sprintf(rpcRescueDelete)
 	}
 
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
+	reply, err := j.execContext(ctx, command)
 	if err != nil {
 		return err
 	}
@@ -628,18 +1026,23 @@ func (j *Junos) Rescue(action string) error {
 
 // RollbackConfig loads and commits the configuration of a given rollback or rescue state.
 func (j *Junos) RollbackConfig(option interface{}) error {
+	return j.RollbackConfigContext(context.Background(), option)
+}
+
+// RollbackConfigContext is RollbackConfig with a caller-supplied context.
+func (j *Junos) RollbackConfigContext(ctx context.Context, option interface{}) error {
 	var command = fmt.Sprintf(rpcRollbackConfig, option)
 
 	if option == "rescue" {
 		command = fmt.Sprintf(rpcRescueConfig)
 	}
 
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
+	reply, err := j.execContext(ctx, command)
 	if err != nil {
 		return err
 	}
 
-	err = j.Commit()
+	err = j.CommitContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -655,7 +1058,12 @@ func (j *Junos) RollbackConfig(option interface{}) error {
 
 // Unlock unlocks the candidate configuration.
 func (j *Junos) Unlock() error {
-	reply, err := j.Session.Exec(netconf.RawRPC(rpcUnlock))
+	return j.UnlockContext(context.Background())
+}
+
+// UnlockContext is Unlock with a caller-supplied context.
+func (j *Junos) UnlockContext(ctx context.Context) error {
+	reply, err := j.execContext(ctx, rpcUnlock)
 	if err != nil {
 		return err
 	}
@@ -671,7 +1079,12 @@ func (j *Junos) Unlock() error {
 
 // Reboot will reboot the device.
 func (j *Junos) Reboot() error {
-	reply, err := j.Session.Exec(netconf.RawRPC(rpcReboot))
+	return j.RebootContext(context.Background())
+}
+
+// RebootContext is Reboot with a caller-supplied context.
+func (j *Junos) RebootContext(ctx context.Context) error {
+	reply, err := j.execContext(ctx, rpcReboot)
 	if err != nil {
 		return err
 	}
@@ -687,12 +1100,17 @@ func (j *Junos) Reboot() error {
 
 // Files will list all of the file and directory information in the given <path>.
 func (j *Junos) Files(path string) (*FileList, error) {
+	return j.FilesContext(context.Background(), path)
+}
+
+// FilesContext is Files with a caller-supplied context.
+func (j *Junos) FilesContext(ctx context.Context, path string) (*FileList, error) {
 	dir := strings.TrimRight(path, "/")
 	var files FileList
 	var command = fmt.Sprintf(rpcFileList, dir+"/")
 	errMessage := "No output available. Please check the syntax of your command."
 
-	reply, err := j.Session.Exec(netconf.RawRPC(command))
+	reply, err := j.execContext(ctx, command)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,142 @@
+package junos
+
+import "testing"
+
+func TestBuildCommitCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CommitOptions
+		want string
+	}{
+		{
+			name: "plain commit",
+			opts: CommitOptions{},
+			want: "<commit-configuration></commit-configuration>",
+		},
+		{
+			name: "check only",
+			opts: CommitOptions{CheckOnly: true},
+			want: "<commit-configuration><check/></commit-configuration>",
+		},
+		{
+			name: "full",
+			opts: CommitOptions{Full: true},
+			want: "<commit-configuration><full/></commit-configuration>",
+		},
+		{
+			name: "synchronize",
+			opts: CommitOptions{Synchronize: true},
+			want: "<commit-configuration><synchronize/></commit-configuration>",
+		},
+		{
+			name: "forced synchronize",
+			opts: CommitOptions{Synchronize: true, Force: true},
+			want: "<commit-configuration><force-synchronize/></commit-configuration>",
+		},
+		{
+			name: "prepare only",
+			opts: CommitOptions{PrepareOnly: true},
+			want: "<commit-configuration><prepare/></commit-configuration>",
+		},
+		{
+			name: "commit prepared",
+			opts: CommitOptions{CommitPrepared: true},
+			want: "<commit-configuration><commit/></commit-configuration>",
+		},
+		{
+			name: "confirmed with explicit timeout",
+			opts: CommitOptions{Confirmed: true, ConfirmTimeout: 5},
+			want: "<commit-configuration><confirmed/><confirm-timeout>5</confirm-timeout></commit-configuration>",
+		},
+		{
+			name: "confirmed defaults to 10 minutes",
+			opts: CommitOptions{Confirmed: true},
+			want: "<commit-configuration><confirmed/><confirm-timeout>10</confirm-timeout></commit-configuration>",
+		},
+		{
+			name: "at a future time",
+			opts: CommitOptions{At: "now + 1 hour"},
+			want: "<commit-configuration><at-time>now + 1 hour</at-time></commit-configuration>",
+		},
+		{
+			name: "with log message",
+			opts: CommitOptions{LogMessage: "automated change"},
+			want: "<commit-configuration><log>automated change</log></commit-configuration>",
+		},
+		{
+			name: "synchronized confirmed commit with log",
+			opts: CommitOptions{Synchronize: true, Force: true, Confirmed: true, ConfirmTimeout: 2, LogMessage: "maintenance"},
+			want: "<commit-configuration><force-synchronize/><confirmed/><confirm-timeout>2</confirm-timeout><log>maintenance</log></commit-configuration>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildCommitCommand(tt.opts); got != tt.want {
+				t.Errorf("buildCommitCommand(%+v) = %q, want %q", tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommitResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "no rpc-errors",
+			data: `<commit-results></commit-results>`,
+		},
+		{
+			name: "only a warning succeeds",
+			data: `
+<commit-results>
+  <rpc-error>
+    <error-path>[edit interfaces ge-0/0/0]</error-path>
+    <error-info><bad-element>unit 0</bad-element></error-info>
+    <error-message>statement has no effect</error-message>
+    <error-severity>warning</error-severity>
+  </rpc-error>
+</commit-results>`,
+		},
+		{
+			name: "an error among warnings fails",
+			data: `
+<commit-results>
+  <rpc-error>
+    <error-message>statement has no effect</error-message>
+    <error-severity>warning</error-severity>
+  </rpc-error>
+  <rpc-error>
+    <error-message>syntax error</error-message>
+    <error-severity>error</error-severity>
+  </rpc-error>
+</commit-results>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseCommitResults(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCommitResults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				return
+			}
+
+			results, ok := err.(CommitErrors)
+			if !ok {
+				t.Fatalf("parseCommitResults() error type = %T, want CommitErrors", err)
+			}
+
+			if len(results) != 2 || results[0].Warning == results[1].Warning {
+				t.Errorf("parseCommitResults() = %+v, want one warning and one error", results)
+			}
+		})
+	}
+}